@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"context"
+	"github.com/DarthPestilane/easytcp/packet"
+	"github.com/DarthPestilane/easytcp/router"
+	"github.com/DarthPestilane/easytcp/session"
+	"github.com/prometheus/client_golang/prometheus"
+	"strconv"
+	"time"
+)
+
+// Metrics returns a middleware that registers and maintains a small set of
+// "invoke statistics" on reg: a counter of messages processed, a gauge of
+// in-flight handlers, and a per-msgId latency histogram.
+func Metrics(reg prometheus.Registerer) router.MiddlewareFunc {
+	processed := registerOrReuse(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "easytcp",
+		Name:      "messages_processed_total",
+		Help:      "Total number of messages processed by the router, by msgId.",
+	}, []string{"msg_id"}))
+	inFlight := registerOrReuse(reg, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "easytcp",
+		Name:      "handlers_in_flight",
+		Help:      "Number of handlers currently executing, by msgId.",
+	}, []string{"msg_id"}))
+	latency := registerOrReuse(reg, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "easytcp",
+		Name:      "handler_latency_seconds",
+		Help:      "Handler execution latency in seconds, by msgId.",
+	}, []string{"msg_id"}))
+
+	return func(next router.HandlerFunc) router.HandlerFunc {
+		return func(ctx context.Context, sess session.Session, msg *packet.Message) error {
+			msgId := strconv.FormatUint(uint64(msg.Id), 10)
+
+			inFlight.WithLabelValues(msgId).Inc()
+			defer inFlight.WithLabelValues(msgId).Dec()
+
+			start := time.Now()
+			err := next(ctx, sess, msg)
+			latency.WithLabelValues(msgId).Observe(time.Since(start).Seconds())
+			processed.WithLabelValues(msgId).Inc()
+			return err
+		}
+	}
+}
+
+// registerOrReuse registers c with reg, or returns the already-registered
+// collector of the same name if Metrics has already been wired up against
+// reg. This keeps repeated calls (e.g. across a reload) idempotent instead
+// of panicking on duplicate registration.
+func registerOrReuse[T prometheus.Collector](reg prometheus.Registerer, c T) T {
+	if err := reg.Register(c); err != nil {
+		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			if existing, ok := are.ExistingCollector.(T); ok {
+				return existing
+			}
+		}
+		panic(err)
+	}
+	return c
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DarthPestilane/easytcp/packet"
+	"github.com/DarthPestilane/easytcp/session"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TestMetrics_RegisterIsIdempotent checks that wiring Metrics up against the
+// same registerer twice (e.g. across a Reload) reuses the existing
+// collectors instead of panicking on duplicate registration.
+func TestMetrics_RegisterIsIdempotent(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	mw := Metrics(reg)
+	Metrics(reg) // must not panic
+
+	sess := &fakeSession{id: "s1"}
+	handler := mw(func(ctx context.Context, sess session.Session, msg *packet.Message) error {
+		return nil
+	})
+	if err := handler(context.Background(), sess, &packet.Message{Id: 7}); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("gather err: %s", err)
+	}
+	var found bool
+	for _, mf := range mfs {
+		if mf.GetName() == "easytcp_messages_processed_total" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("expected easytcp_messages_processed_total to be registered")
+	}
+}
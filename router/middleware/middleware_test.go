@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"github.com/DarthPestilane/easytcp/packet"
+	"github.com/DarthPestilane/easytcp/session"
+)
+
+// fakeSession is a minimal session.Session for exercising middleware without
+// a real transport.
+type fakeSession struct {
+	id      string
+	msgChan chan *packet.Message
+	closed  bool
+}
+
+var _ session.Session = &fakeSession{}
+
+func (f *fakeSession) ID() string                     { return f.id }
+func (f *fakeSession) MsgChan() <-chan *packet.Message { return f.msgChan }
+func (f *fakeSession) Send(msg *packet.Message)        {}
+func (f *fakeSession) ReadLoop()                       {}
+func (f *fakeSession) WriteLoop()                      {}
+func (f *fakeSession) WaitUntilClosed()                {}
+func (f *fakeSession) Close()                          { f.closed = true }
+func (f *fakeSession) Codec() packet.Codec             { return nil }
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"github.com/DarthPestilane/easytcp/logger"
+	"github.com/DarthPestilane/easytcp/packet"
+	"github.com/DarthPestilane/easytcp/router"
+	"github.com/DarthPestilane/easytcp/session"
+	"runtime/debug"
+)
+
+var log = logger.Default.WithField("scope", "router.middleware")
+
+// Recover returns a middleware that recovers from panics raised by handlers
+// further down the chain, logs the stack trace, and closes the session that
+// panicked so a single bad message can't wedge the whole server.
+func Recover() router.MiddlewareFunc {
+	return func(next router.HandlerFunc) router.HandlerFunc {
+		return func(ctx context.Context, sess session.Session, msg *packet.Message) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					log.WithField("sid", sess.ID()).Errorf("handler panic: %v\n%s", r, debug.Stack())
+					sess.Close()
+					err = fmt.Errorf("handler panic: %v", r)
+				}
+			}()
+			return next(ctx, sess, msg)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DarthPestilane/easytcp/packet"
+	"github.com/DarthPestilane/easytcp/session"
+)
+
+func TestRecover_RecoversPanicAndClosesSession(t *testing.T) {
+	sess := &fakeSession{id: "s1"}
+	handler := Recover()(func(ctx context.Context, sess session.Session, msg *packet.Message) error {
+		panic("boom")
+	})
+
+	err := handler(context.Background(), sess, &packet.Message{Id: 1})
+	if err == nil {
+		t.Fatal("expected the recovered panic to surface as an error")
+	}
+	if !sess.closed {
+		t.Fatal("expected Recover to close the session after a panic")
+	}
+}
+
+func TestRecover_PassesThroughNonPanickingHandler(t *testing.T) {
+	sess := &fakeSession{id: "s1"}
+	var called bool
+	handler := Recover()(func(ctx context.Context, sess session.Session, msg *packet.Message) error {
+		called = true
+		return nil
+	})
+
+	if err := handler(context.Background(), sess, &packet.Message{Id: 1}); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if !called {
+		t.Fatal("expected the wrapped handler to run")
+	}
+	if sess.closed {
+		t.Fatal("expected the session to stay open when the handler doesn't panic")
+	}
+}
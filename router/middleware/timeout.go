@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"context"
+	"github.com/DarthPestilane/easytcp/packet"
+	"github.com/DarthPestilane/easytcp/router"
+	"github.com/DarthPestilane/easytcp/session"
+	"time"
+)
+
+// Timeout returns a middleware that cancels the handler's context after d.
+// Handlers that check ctx.Done() (e.g. before a slow downstream call) can use
+// this to bail out early; it does not forcibly preempt a handler that never
+// looks at ctx.
+func Timeout(d time.Duration) router.MiddlewareFunc {
+	return func(next router.HandlerFunc) router.HandlerFunc {
+		return func(ctx context.Context, sess session.Session, msg *packet.Message) error {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+			return next(ctx, sess, msg)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DarthPestilane/easytcp/packet"
+	"github.com/DarthPestilane/easytcp/session"
+)
+
+func TestTimeout_CancelsContextAfterDuration(t *testing.T) {
+	sess := &fakeSession{id: "s1"}
+	var sawDeadline bool
+	handler := Timeout(10 * time.Millisecond)(func(ctx context.Context, sess session.Session, msg *packet.Message) error {
+		<-ctx.Done()
+		sawDeadline = errors.Is(ctx.Err(), context.DeadlineExceeded)
+		return ctx.Err()
+	})
+
+	err := handler(context.Background(), sess, &packet.Message{Id: 1})
+	if !sawDeadline {
+		t.Fatal("expected the handler's ctx to be canceled with DeadlineExceeded")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestTimeout_DoesNotAffectFastHandler(t *testing.T) {
+	sess := &fakeSession{id: "s1"}
+	handler := Timeout(time.Second)(func(ctx context.Context, sess session.Session, msg *packet.Message) error {
+		return nil
+	})
+
+	if err := handler(context.Background(), sess, &packet.Message{Id: 1}); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+}
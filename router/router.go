@@ -0,0 +1,104 @@
+package router
+
+import (
+	"context"
+	"github.com/DarthPestilane/easytcp/packet"
+	"github.com/DarthPestilane/easytcp/session"
+	"sync"
+)
+
+// HandlerFunc handles a single decoded message for sess. ctx is canceled
+// once sess closes, so handlers and middleware can bail out of long-running
+// work instead of outliving the session.
+type HandlerFunc func(ctx context.Context, sess session.Session, msg *packet.Message) error
+
+// MiddlewareFunc wraps a HandlerFunc with cross-cutting behaviour (recovery,
+// timeouts, metrics, ...), returning a new HandlerFunc that runs before and
+// after calling next.
+type MiddlewareFunc func(next HandlerFunc) HandlerFunc
+
+// Router dispatches messages read off a session to the handler registered
+// for their msgId, wrapped by the router's global middlewares and any
+// middlewares passed to Register for that route.
+type Router struct {
+	mu          sync.Mutex
+	routes      map[uint]HandlerFunc // per-route handlers, already wrapped with their own middlewares
+	middlewares []MiddlewareFunc
+	dispatch    map[uint]HandlerFunc // routes wrapped with the current global middlewares, memoized
+}
+
+// New creates an empty Router.
+func New() *Router {
+	return &Router{routes: make(map[uint]HandlerFunc), dispatch: make(map[uint]HandlerFunc)}
+}
+
+// Register associates handler with msgId, wrapping it with middlewares
+// (the first one listed outermost). These run closer to the handler than the router's
+// global middlewares, which always wrap outermost.
+func (r *Router) Register(msgId uint, handler HandlerFunc, middlewares ...MiddlewareFunc) {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.routes[msgId] = handler
+	delete(r.dispatch, msgId)
+}
+
+// RegisterMiddleware appends middlewares to the router's global chain, run
+// for every route ahead of any middlewares passed to Register.
+func (r *Router) RegisterMiddleware(middlewares ...MiddlewareFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.middlewares = append(r.middlewares, middlewares...)
+	r.dispatch = make(map[uint]HandlerFunc) // global chain changed; recompute lazily per msgId
+}
+
+// handlerFor returns the fully-wrapped handler for msgId, building and
+// caching it on first use so Loop doesn't re-wrap the global middleware
+// chain on every single message.
+func (r *Router) handlerFor(msgId uint) (HandlerFunc, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if handler, ok := r.dispatch[msgId]; ok {
+		return handler, true
+	}
+	route, ok := r.routes[msgId]
+	if !ok {
+		return nil, false
+	}
+	handler := route
+	for i := len(r.middlewares) - 1; i >= 0; i-- {
+		handler = r.middlewares[i](handler)
+	}
+	r.dispatch[msgId] = handler
+	return handler, true
+}
+
+// Loop reads decoded messages off sess until it's closed, dispatching each
+// to its registered handler wrapped with the router's global middlewares.
+// A message with no registered handler is dropped.
+func (r *Router) Loop(sess session.Session) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		sess.WaitUntilClosed()
+		cancel()
+	}()
+
+	for {
+		select {
+		case msg, ok := <-sess.MsgChan():
+			if !ok {
+				return
+			}
+			handler, ok := r.handlerFor(msg.Id)
+			if !ok {
+				continue
+			}
+			_ = handler(ctx, sess, msg)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/DarthPestilane/easytcp/session"
+)
+
+// TestTcpServer_AcceptLoop_MaxConnsBlocksAccept checks that the connSem
+// semaphore is acquired before AcceptTCP, so MaxConns bounds the number of
+// accepted connections, not just the number handed off to handleConn.
+func TestTcpServer_AcceptLoop_MaxConnsBlocksAccept(t *testing.T) {
+	s := NewTcpServer(TcpOption{MaxConns: 1})
+	go func() { _ = s.Serve("127.0.0.1:0") }()
+	<-s.accepting
+
+	conn1, err := net.Dial("tcp", s.Addr().String())
+	if err != nil {
+		t.Fatalf("dial err: %s", err)
+	}
+	defer conn1.Close()
+	time.Sleep(50 * time.Millisecond) // let acceptLoop accept conn1 and take the one semaphore slot
+
+	if len(s.connSem) != 1 {
+		t.Fatalf("expected the semaphore slot to be held by conn1's handleConn, got len=%d", len(s.connSem))
+	}
+
+	conn2, err := net.Dial("tcp", s.Addr().String())
+	if err != nil {
+		t.Fatalf("dial err: %s", err)
+	}
+	defer conn2.Close()
+	time.Sleep(50 * time.Millisecond) // acceptLoop should still be blocked on the semaphore, not AcceptTCP-ing conn2
+
+	tracked := 0
+	session.Sessions().Range(func(id string, sess session.Session) (next bool) {
+		tracked++
+		return true
+	})
+	if tracked != 1 {
+		t.Fatalf("expected only conn1 to have been accepted while MaxConns=1 is exhausted, got %d tracked session(s)", tracked)
+	}
+}
+
+func TestNextAcceptBackoff(t *testing.T) {
+	cases := []struct {
+		prev time.Duration
+		want time.Duration
+	}{
+		{0, 5 * time.Millisecond},
+		{5 * time.Millisecond, 10 * time.Millisecond},
+		{700 * time.Millisecond, time.Second},
+		{time.Second, time.Second},
+	}
+	for _, c := range cases {
+		if got := nextAcceptBackoff(c.prev); got != c.want {
+			t.Errorf("nextAcceptBackoff(%s) = %s, want %s", c.prev, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package server
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// TestTcpServer_SocketOptions_DontBreakAcceptLoop is a smoke test for the
+// KeepAlive/NoDelay/Linger options: applying them to an accepted conn must
+// not error out and tear down acceptLoop, which would silently stop the
+// server from accepting any further connections.
+func TestTcpServer_SocketOptions_DontBreakAcceptLoop(t *testing.T) {
+	noDelay := true
+	linger := 0
+	s := NewTcpServer(TcpOption{
+		KeepAlive: time.Second,
+		NoDelay:   &noDelay,
+		Linger:    &linger,
+	})
+	go func() { _ = s.Serve("127.0.0.1:0") }()
+	<-s.accepting
+
+	conn1, err := net.Dial("tcp", s.Addr().String())
+	if err != nil {
+		t.Fatalf("dial err: %s", err)
+	}
+	defer conn1.Close()
+	time.Sleep(50 * time.Millisecond)
+
+	// A second successful dial proves acceptLoop is still running, i.e. the
+	// syscalls applied to conn1 didn't return an error that killed the loop.
+	conn2, err := net.Dial("tcp", s.Addr().String())
+	if err != nil {
+		t.Fatalf("dial err: %s", err)
+	}
+	defer conn2.Close()
+}
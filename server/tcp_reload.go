@@ -0,0 +1,63 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"os"
+)
+
+// listenerFd is the fd number the listener is passed on when inherited by a
+// child process across a Reload. Matches the convention used by goagain and
+// similar zero-downtime restart tools: stdin, stdout, stderr, then listener.
+const listenerFd = 3
+
+// ListenerFile returns the *os.File backing the TCP listener, suitable for
+// passing to a child process via os.ProcAttr.Files.
+func (s *TcpServer) ListenerFile() (*os.File, error) {
+	return s.listener.File()
+}
+
+// ServeFromEnv behaves like Serve(addr), except when envVar is set it
+// reconstructs the listener from the inherited fd 3 instead of calling
+// ListenTCP. This lets a freshly exec'd process pick up right where its
+// predecessor left off, without ever closing the listening socket.
+func (s *TcpServer) ServeFromEnv(addr, envVar string) error {
+	if os.Getenv(envVar) == "" {
+		return s.Serve(addr)
+	}
+
+	file := os.NewFile(uintptr(listenerFd), "listener")
+	lis, err := net.FileListener(file)
+	if err != nil {
+		return fmt.Errorf("file listener err: %s", err)
+	}
+	tcpLis, ok := lis.(*net.TCPListener)
+	if !ok {
+		return fmt.Errorf("inherited fd %d is not a tcp listener", listenerFd)
+	}
+	s.listener = tcpLis
+
+	return s.acceptLoop()
+}
+
+// Reload starts a new copy of the running binary, handing it the listener's
+// fd so it can start accepting before this process shuts down. It does not
+// drain this process's own sessions; callers are expected to follow up with
+// Shutdown once the new process is up. Callers typically wire this up to
+// SIGHUP.
+func (s *TcpServer) Reload(envVar string) (*os.Process, error) {
+	file, err := s.ListenerFile()
+	if err != nil {
+		return nil, fmt.Errorf("listener file err: %s", err)
+	}
+	defer file.Close()
+
+	proc, err := os.StartProcess(os.Args[0], os.Args, &os.ProcAttr{
+		Env:   append(os.Environ(), envVar+"=1"),
+		Files: []*os.File{os.Stdin, os.Stdout, os.Stderr, file},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("start process err: %s", err)
+	}
+	return proc, nil
+}
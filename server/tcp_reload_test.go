@@ -0,0 +1,60 @@
+package server
+
+import (
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestServeFromEnv_FallsBackToServeWhenEnvUnset(t *testing.T) {
+	const envVar = "EASYTCP_TEST_LISTENER_FD_UNSET"
+	if err := os.Unsetenv(envVar); err != nil {
+		t.Fatalf("unsetenv err: %s", err)
+	}
+
+	s := NewTcpServer(TcpOption{})
+	go func() { _ = s.ServeFromEnv("127.0.0.1:0", envVar) }()
+	<-s.accepting
+
+	if s.Addr() == nil {
+		t.Fatal("expected ServeFromEnv to fall back to Serve and bind a listener")
+	}
+}
+
+func TestServeFromEnv_ReconstructsListenerFromInheritedFd(t *testing.T) {
+	const envVar = "EASYTCP_TEST_LISTENER_FD_SET"
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen err: %s", err)
+	}
+	tcpLis := lis.(*net.TCPListener)
+	file, err := tcpLis.File()
+	if err != nil {
+		t.Fatalf("listener file err: %s", err)
+	}
+	defer file.Close()
+	if err := tcpLis.Close(); err != nil {
+		t.Fatalf("close original listener err: %s", err)
+	}
+
+	if err := syscall.Dup2(int(file.Fd()), listenerFd); err != nil {
+		t.Fatalf("dup2 err: %s", err)
+	}
+	defer syscall.Close(listenerFd)
+
+	if err := os.Setenv(envVar, "1"); err != nil {
+		t.Fatalf("setenv err: %s", err)
+	}
+	defer os.Unsetenv(envVar)
+
+	s := NewTcpServer(TcpOption{})
+	go func() { _ = s.ServeFromEnv("ignored:0", envVar) }()
+	time.Sleep(50 * time.Millisecond)
+
+	if s.listener == nil {
+		t.Fatal("expected ServeFromEnv to reconstruct the listener from the inherited fd")
+	}
+}
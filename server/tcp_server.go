@@ -1,31 +1,54 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/DarthPestilane/easytcp/logger"
 	"github.com/DarthPestilane/easytcp/packet"
 	"github.com/DarthPestilane/easytcp/router"
 	"github.com/DarthPestilane/easytcp/session"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
 	"net"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// ErrServerClosed is returned by acceptLoop when the listener was closed by
+// Stop or Shutdown, so callers can distinguish a deliberate shutdown from an
+// actual accept failure.
+var ErrServerClosed = errors.New("easytcp: server closed")
+
 type TcpServer struct {
-	rwBufferSize int
-	listener     *net.TCPListener
-	log          *logrus.Entry
-	msgPacker    packet.Packer
-	msgCodec     packet.Codec
-	accepting    chan struct{}
-	router       *router.Router
+	rwBufferSize  int
+	keepAlive     time.Duration
+	noDelay       *bool
+	linger        *int
+	listener      *net.TCPListener
+	log           *logrus.Entry
+	msgPacker     packet.Packer
+	msgCodec      packet.Codec
+	accepting     chan struct{}
+	router        *router.Router
+	connWg        sync.WaitGroup // tracks in-flight handleConn goroutines, used by Shutdown
+	connSem       chan struct{}  // bounds concurrent connections to MaxConns, nil means unbounded
+	acceptLimiter *rate.Limiter  // throttles AcceptTCP calls, nil means unthrottled
+	closed        atomic.Bool    // set by Stop/Shutdown so acceptLoop can return ErrServerClosed
 }
 
 var _ Server = &TcpServer{}
 
 type TcpOption struct {
-	RWBufferSize int           // socket 读写 buffer
-	MsgPacker    packet.Packer // 消息封包/拆包器
-	MsgCodec     packet.Codec  // 消息编码/解码器
+	RWBufferSize    int           // socket 读写 buffer
+	MsgPacker       packet.Packer // 消息封包/拆包器
+	MsgCodec        packet.Codec  // 消息编码/解码器
+	KeepAlive       time.Duration // tcp keep-alive period; <= 0 leaves the listener's default keep-alive setting
+	NoDelay         *bool         // disables Nagle's algorithm when true; nil leaves the OS default
+	Linger          *int          // SO_LINGER seconds; nil leaves the OS default
+	MaxConns        int           // max concurrent connections; <= 0 means unbounded
+	AcceptRateLimit rate.Limit    // max AcceptTCP calls per second; <= 0 means unthrottled
 }
 
 func NewTcpServer(opt TcpOption) *TcpServer {
@@ -35,14 +58,24 @@ func NewTcpServer(opt TcpOption) *TcpServer {
 	if opt.MsgCodec == nil {
 		opt.MsgCodec = &packet.StringCodec{}
 	}
-	return &TcpServer{
+	s := &TcpServer{
 		log:          logger.Default.WithField("scope", "server.TcpServer"),
 		rwBufferSize: opt.RWBufferSize,
+		keepAlive:    opt.KeepAlive,
+		noDelay:      opt.NoDelay,
+		linger:       opt.Linger,
 		msgPacker:    opt.MsgPacker,
 		msgCodec:     opt.MsgCodec,
 		accepting:    make(chan struct{}),
 		router:       router.New(),
 	}
+	if opt.MaxConns > 0 {
+		s.connSem = make(chan struct{}, opt.MaxConns)
+	}
+	if opt.AcceptRateLimit > 0 {
+		s.acceptLimiter = rate.NewLimiter(opt.AcceptRateLimit, 1)
+	}
+	return s
 }
 
 func (s *TcpServer) Serve(addr string) error {
@@ -61,11 +94,36 @@ func (s *TcpServer) Serve(addr string) error {
 
 func (s *TcpServer) acceptLoop() error {
 	close(s.accepting)
+	var tempDelay time.Duration
 	for {
+		if s.acceptLimiter != nil {
+			if err := s.acceptLimiter.Wait(context.Background()); err != nil {
+				return fmt.Errorf("accept rate limiter err: %s", err)
+			}
+		}
+
+		if s.connSem != nil {
+			s.connSem <- struct{}{}
+		}
+
 		conn, err := s.listener.AcceptTCP()
 		if err != nil {
+			if s.connSem != nil {
+				<-s.connSem
+			}
+			if s.closed.Load() {
+				return ErrServerClosed
+			}
+			if ne, ok := err.(net.Error); ok && ne.Temporary() { //nolint:staticcheck // mirrors net/http.Server.Serve
+				tempDelay = nextAcceptBackoff(tempDelay)
+				s.log.Tracef("accept err: %s; retrying in %s", err, tempDelay)
+				time.Sleep(tempDelay)
+				continue
+			}
 			return fmt.Errorf("accept err: %s", err)
 		}
+		tempDelay = 0
+
 		if s.rwBufferSize > 0 {
 			if err := conn.SetReadBuffer(s.rwBufferSize); err != nil {
 				return fmt.Errorf("conn set read buffer err: %s", err)
@@ -74,12 +132,45 @@ func (s *TcpServer) acceptLoop() error {
 				return fmt.Errorf("conn set write buffer err: %s", err)
 			}
 		}
+		if s.keepAlive > 0 {
+			if err := conn.SetKeepAlive(true); err != nil {
+				return fmt.Errorf("conn set keep-alive err: %s", err)
+			}
+			if err := conn.SetKeepAlivePeriod(s.keepAlive); err != nil {
+				return fmt.Errorf("conn set keep-alive period err: %s", err)
+			}
+		}
+		if s.noDelay != nil {
+			if err := conn.SetNoDelay(*s.noDelay); err != nil {
+				return fmt.Errorf("conn set no-delay err: %s", err)
+			}
+		}
+		if s.linger != nil {
+			if err := conn.SetLinger(*s.linger); err != nil {
+				return fmt.Errorf("conn set linger err: %s", err)
+			}
+		}
 
 		// handle conn in a new goroutine
+		s.connWg.Add(1)
 		go s.handleConn(conn)
 	}
 }
 
+// nextAcceptBackoff returns the next retry delay for a temporary AcceptTCP
+// error, doubling from an initial 5ms up to a 1s cap, matching
+// net/http.Server.Serve's backoff.
+func nextAcceptBackoff(prev time.Duration) time.Duration {
+	if prev == 0 {
+		return 5 * time.Millisecond
+	}
+	d := prev * 2
+	if max := time.Second; d > max {
+		d = max
+	}
+	return d
+}
+
 // handleConn
 // create a new session and save it to memory
 // read/write loop
@@ -87,6 +178,10 @@ func (s *TcpServer) acceptLoop() error {
 // wait for session to close
 // remove session from memory
 func (s *TcpServer) handleConn(conn *net.TCPConn) {
+	defer s.connWg.Done()
+	if s.connSem != nil {
+		defer func() { <-s.connSem }()
+	}
 	sess := session.NewTcp(conn, s.msgPacker, s.msgCodec)
 	session.Sessions().Add(sess)
 	go s.router.Loop(sess)
@@ -102,6 +197,7 @@ func (s *TcpServer) handleConn(conn *net.TCPConn) {
 
 // Stop stops server and closes all the tcp sessions
 func (s *TcpServer) Stop() error {
+	s.closed.Store(true)
 	closedNum := 0
 	session.Sessions().Range(func(id string, sess session.Session) (next bool) {
 		if tcpSess, ok := sess.(*session.TcpSession); ok {
@@ -114,6 +210,44 @@ func (s *TcpServer) Stop() error {
 	return s.listener.Close()
 }
 
+// Addr returns the listener's network address.
+// Useful when the server is started on ":0" in tests.
+func (s *TcpServer) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Shutdown stops accepting new connections and waits for in-flight handleConn
+// goroutines to finish, up to ctx's deadline. Sessions still running once the
+// deadline expires are force-closed instead of waited on further.
+func (s *TcpServer) Shutdown(ctx context.Context) error {
+	s.closed.Store(true)
+	if err := s.listener.Close(); err != nil {
+		s.log.Tracef("listener close err: %s", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.connWg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		closedNum := 0
+		session.Sessions().Range(func(id string, sess session.Session) (next bool) {
+			if tcpSess, ok := sess.(*session.TcpSession); ok {
+				tcpSess.Close()
+				closedNum++
+			}
+			return true
+		})
+		s.log.Tracef("shutdown deadline exceeded, force-closed %d straggling session(s)", closedNum)
+		return ctx.Err()
+	}
+}
+
 func (s *TcpServer) AddRoute(msgId uint, handler router.HandlerFunc, middlewares ...router.MiddlewareFunc) {
 	s.router.Register(msgId, handler, middlewares...)
 }
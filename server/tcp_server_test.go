@@ -0,0 +1,54 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestTcpServer_Shutdown_DrainsClosedSessions checks that once a connection's
+// session has closed on its own (e.g. the peer hung up), Shutdown's connWg
+// wait unblocks promptly instead of waiting out the full deadline.
+func TestTcpServer_Shutdown_DrainsClosedSessions(t *testing.T) {
+	s := NewTcpServer(TcpOption{})
+	go func() { _ = s.Serve("127.0.0.1:0") }()
+	<-s.accepting
+
+	conn, err := net.Dial("tcp", s.Addr().String())
+	if err != nil {
+		t.Fatalf("dial err: %s", err)
+	}
+	if err := conn.Close(); err != nil {
+		t.Fatalf("conn close err: %s", err)
+	}
+	time.Sleep(50 * time.Millisecond) // let ReadLoop observe the close and finish handleConn
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown err: %s", err)
+	}
+}
+
+// TestTcpServer_Shutdown_ForceClosesStragglers checks that a still-open
+// session is force-closed and Shutdown returns ctx.Err() once the deadline
+// passes, rather than blocking on connWg forever.
+func TestTcpServer_Shutdown_ForceClosesStragglers(t *testing.T) {
+	s := NewTcpServer(TcpOption{})
+	go func() { _ = s.Serve("127.0.0.1:0") }()
+	<-s.accepting
+
+	conn, err := net.Dial("tcp", s.Addr().String())
+	if err != nil {
+		t.Fatalf("dial err: %s", err)
+	}
+	defer conn.Close()
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"github.com/DarthPestilane/easytcp/logger"
+	"github.com/DarthPestilane/easytcp/packet"
+	"github.com/DarthPestilane/easytcp/router"
+	"github.com/DarthPestilane/easytcp/session"
+	"github.com/sirupsen/logrus"
+	"net"
+	"sync"
+)
+
+type UdpServer struct {
+	rwBufferSize int
+	listener     *net.UDPConn
+	log          *logrus.Entry
+	msgPacker    packet.Packer
+	msgCodec     packet.Codec
+	router       *router.Router
+	sessMu       sync.Mutex
+	sessByAddr   map[string]*session.UdpSession
+	connWg       sync.WaitGroup
+}
+
+var _ Server = &UdpServer{}
+
+type UdpOption struct {
+	RWBufferSize int           // socket 读写 buffer
+	MsgPacker    packet.Packer // 消息封包/拆包器
+	MsgCodec     packet.Codec  // 消息编码/解码器
+}
+
+func NewUdpServer(opt UdpOption) *UdpServer {
+	if opt.MsgPacker == nil {
+		opt.MsgPacker = &packet.DefaultPacker{}
+	}
+	if opt.MsgCodec == nil {
+		opt.MsgCodec = &packet.StringCodec{}
+	}
+	return &UdpServer{
+		log:          logger.Default.WithField("scope", "server.UdpServer"),
+		rwBufferSize: opt.RWBufferSize,
+		msgPacker:    opt.MsgPacker,
+		msgCodec:     opt.MsgCodec,
+		router:       router.New(),
+		sessByAddr:   make(map[string]*session.UdpSession),
+	}
+}
+
+func (s *UdpServer) Serve(addr string) error {
+	address, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return err
+	}
+	conn, err := net.ListenUDP("udp", address)
+	if err != nil {
+		return err
+	}
+	if s.rwBufferSize > 0 {
+		if err := conn.SetReadBuffer(s.rwBufferSize); err != nil {
+			return fmt.Errorf("conn set read buffer err: %s", err)
+		}
+		if err := conn.SetWriteBuffer(s.rwBufferSize); err != nil {
+			return fmt.Errorf("conn set write buffer err: %s", err)
+		}
+	}
+	s.listener = conn
+
+	return s.readLoop()
+}
+
+// readLoop reads datagrams off the shared conn, demultiplexes them to the
+// session keyed by remote addr (creating one on first sight), and dispatches
+// the decoded message to the router.
+func (s *UdpServer) readLoop() error {
+	buf := make([]byte, 64*1024)
+	for {
+		n, remoteAddr, err := s.listener.ReadFromUDP(buf)
+		if err != nil {
+			return fmt.Errorf("read err: %s", err)
+		}
+		data := make([]byte, n)
+		copy(data, buf[:n])
+
+		sess := s.sessionFor(remoteAddr)
+		sess.Dispatch(data)
+	}
+}
+
+func (s *UdpServer) sessionFor(remoteAddr *net.UDPAddr) *session.UdpSession {
+	key := remoteAddr.String()
+
+	s.sessMu.Lock()
+	sess, ok := s.sessByAddr[key]
+	if !ok {
+		sess = session.NewUdp(s.listener, remoteAddr, s.msgPacker, s.msgCodec)
+		s.sessByAddr[key] = sess
+		s.sessMu.Unlock()
+
+		session.Sessions().Add(sess)
+		s.connWg.Add(1)
+		go s.handleSession(sess, key)
+		return sess
+	}
+	s.sessMu.Unlock()
+	return sess
+}
+
+// handleSession routes a single udp session until it's closed, then removes
+// it from both the local and global session registries.
+func (s *UdpServer) handleSession(sess *session.UdpSession, key string) {
+	defer s.connWg.Done()
+	go s.router.Loop(sess)
+	go sess.ReadLoop()
+	go sess.WriteLoop()
+	sess.WaitUntilClosed()
+
+	s.sessMu.Lock()
+	delete(s.sessByAddr, key)
+	s.sessMu.Unlock()
+
+	session.Sessions().Remove(sess.ID())
+	s.log.WithField("sid", sess.ID()).Tracef("session closed")
+}
+
+// Stop stops the server and closes all the udp sessions.
+func (s *UdpServer) Stop() error {
+	closedNum := 0
+	session.Sessions().Range(func(id string, sess session.Session) (next bool) {
+		if udpSess, ok := sess.(*session.UdpSession); ok {
+			udpSess.Close()
+			closedNum++
+		}
+		return true
+	})
+	s.log.Tracef("%d session(s) closed", closedNum)
+	return s.listener.Close()
+}
+
+// Shutdown stops accepting new datagrams and drains in-flight sessions until
+// ctx's deadline, then force-closes any stragglers.
+func (s *UdpServer) Shutdown(ctx context.Context) error {
+	if err := s.listener.Close(); err != nil {
+		s.log.Tracef("listener close err: %s", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.connWg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		closedNum := 0
+		session.Sessions().Range(func(id string, sess session.Session) (next bool) {
+			if udpSess, ok := sess.(*session.UdpSession); ok {
+				udpSess.Close()
+				closedNum++
+			}
+			return true
+		})
+		s.log.Tracef("shutdown deadline exceeded, force-closed %d straggling session(s)", closedNum)
+		return ctx.Err()
+	}
+}
+
+func (s *UdpServer) AddRoute(msgId uint, handler router.HandlerFunc, middlewares ...router.MiddlewareFunc) {
+	s.router.Register(msgId, handler, middlewares...)
+}
+
+func (s *UdpServer) Use(middlewares ...router.MiddlewareFunc) {
+	s.router.RegisterMiddleware(middlewares...)
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/DarthPestilane/easytcp/session"
+)
+
+// TestUdpServer_SessionFor_ReusesSessionForSameAddr checks the demuxing
+// UdpServer.sessionFor relies on: repeated datagrams from the same remote
+// addr must land on the same session, not a new one each time.
+func TestUdpServer_SessionFor_ReusesSessionForSameAddr(t *testing.T) {
+	s := NewUdpServer(UdpOption{})
+	go func() { _ = s.Serve("127.0.0.1:0") }()
+	time.Sleep(50 * time.Millisecond)
+
+	remoteAddr := &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 12345}
+	sess1 := s.sessionFor(remoteAddr)
+	sess2 := s.sessionFor(remoteAddr)
+	if sess1 != sess2 {
+		t.Fatal("expected sessionFor to reuse the existing session for the same remote addr")
+	}
+}
+
+func TestUdpServer_Shutdown_DrainsClosedSessions(t *testing.T) {
+	s := NewUdpServer(UdpOption{})
+	go func() { _ = s.Serve("127.0.0.1:0") }()
+	time.Sleep(50 * time.Millisecond)
+
+	conn, err := net.Dial("udp", s.listener.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("dial err: %s", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("x")); err != nil {
+		t.Fatalf("write err: %s", err)
+	}
+	time.Sleep(50 * time.Millisecond) // let readLoop create the session for this remote addr
+
+	session.Sessions().Range(func(id string, sess session.Session) (next bool) {
+		if udpSess, ok := sess.(*session.UdpSession); ok {
+			udpSess.Close()
+		}
+		return true
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown err: %s", err)
+	}
+}
+
+func TestUdpServer_Shutdown_ForceClosesStragglers(t *testing.T) {
+	s := NewUdpServer(UdpOption{})
+	go func() { _ = s.Serve("127.0.0.1:0") }()
+	time.Sleep(50 * time.Millisecond)
+
+	conn, err := net.Dial("udp", s.listener.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("dial err: %s", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("x")); err != nil {
+		t.Fatalf("write err: %s", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestUdpServer_Stop_ClosesListener(t *testing.T) {
+	s := NewUdpServer(UdpOption{})
+	go func() { _ = s.Serve("127.0.0.1:0") }()
+	time.Sleep(50 * time.Millisecond)
+
+	if err := s.Stop(); err != nil {
+		t.Fatalf("stop err: %s", err)
+	}
+}
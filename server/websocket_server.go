@@ -0,0 +1,135 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"github.com/DarthPestilane/easytcp/logger"
+	"github.com/DarthPestilane/easytcp/packet"
+	"github.com/DarthPestilane/easytcp/router"
+	"github.com/DarthPestilane/easytcp/session"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"sync"
+)
+
+type WebsocketServer struct {
+	msgPacker packet.Packer
+	msgCodec  packet.Codec
+	upgrader  *websocket.Upgrader
+	httpSrv   *http.Server
+	log       *logrus.Entry
+	router    *router.Router
+	connWg    sync.WaitGroup
+}
+
+var _ Server = &WebsocketServer{}
+
+type WsOption struct {
+	MsgPacker packet.Packer // 消息封包/拆包器
+	MsgCodec  packet.Codec  // 消息编码/解码器
+}
+
+func NewWebsocketServer(opt WsOption) *WebsocketServer {
+	if opt.MsgPacker == nil {
+		opt.MsgPacker = &packet.DefaultPacker{}
+	}
+	if opt.MsgCodec == nil {
+		opt.MsgCodec = &packet.StringCodec{}
+	}
+	return &WebsocketServer{
+		msgPacker: opt.MsgPacker,
+		msgCodec:  opt.MsgCodec,
+		upgrader:  &websocket.Upgrader{},
+		log:       logger.Default.WithField("scope", "server.WebsocketServer"),
+		router:    router.New(),
+	}
+}
+
+func (s *WebsocketServer) Serve(addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.handleHttp)
+	s.httpSrv = &http.Server{Addr: addr, Handler: mux}
+	if err := s.httpSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("serve err: %s", err)
+	}
+	return nil
+}
+
+func (s *WebsocketServer) handleHttp(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		s.log.Tracef("upgrade err: %s", err)
+		return
+	}
+	s.connWg.Add(1)
+	go s.handleConn(conn)
+}
+
+// handleConn creates a session for conn, routes its incoming messages, and
+// cleans up once the session closes.
+func (s *WebsocketServer) handleConn(conn *websocket.Conn) {
+	defer s.connWg.Done()
+	sess := session.NewWs(conn, s.msgPacker, s.msgCodec)
+	session.Sessions().Add(sess)
+	go s.router.Loop(sess)
+	go sess.ReadLoop()
+	go sess.WriteLoop()
+	sess.WaitUntilClosed()
+	session.Sessions().Remove(sess.ID())
+	s.log.WithField("sid", sess.ID()).Tracef("session closed")
+}
+
+// Stop stops the server and closes all the websocket sessions.
+func (s *WebsocketServer) Stop() error {
+	closedNum := 0
+	session.Sessions().Range(func(id string, sess session.Session) (next bool) {
+		if wsSess, ok := sess.(*session.WsSession); ok {
+			wsSess.Close()
+			closedNum++
+		}
+		return true
+	})
+	s.log.Tracef("%d session(s) closed", closedNum)
+	return s.httpSrv.Close()
+}
+
+// Shutdown stops accepting new connections via http.Server.Shutdown, then
+// drains in-flight sessions until ctx's deadline. http.Server.Shutdown only
+// waits on idle connections, not ones it has handed off via Upgrade, so
+// connWg is what actually tracks the upgraded sessions here.
+func (s *WebsocketServer) Shutdown(ctx context.Context) error {
+	if err := s.httpSrv.Shutdown(ctx); err != nil {
+		s.log.Tracef("http server shutdown err: %s", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.connWg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		closedNum := 0
+		session.Sessions().Range(func(id string, sess session.Session) (next bool) {
+			if wsSess, ok := sess.(*session.WsSession); ok {
+				wsSess.Close()
+				closedNum++
+			}
+			return true
+		})
+		s.log.Tracef("shutdown deadline exceeded, force-closed %d straggling session(s)", closedNum)
+		return ctx.Err()
+	}
+}
+
+func (s *WebsocketServer) AddRoute(msgId uint, handler router.HandlerFunc, middlewares ...router.MiddlewareFunc) {
+	s.router.Register(msgId, handler, middlewares...)
+}
+
+func (s *WebsocketServer) Use(middlewares ...router.MiddlewareFunc) {
+	s.router.RegisterMiddleware(middlewares...)
+}
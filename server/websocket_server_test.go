@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/DarthPestilane/easytcp/session"
+	"github.com/gorilla/websocket"
+)
+
+// freeAddr finds an address that's free at the time of the call, for
+// WebsocketServer tests that need a concrete addr before Serve starts (it
+// doesn't expose its listener the way TcpServer does).
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen err: %s", err)
+	}
+	addr := lis.Addr().String()
+	if err := lis.Close(); err != nil {
+		t.Fatalf("close err: %s", err)
+	}
+	return addr
+}
+
+func TestWebsocketServer_Shutdown_DrainsClosedSessions(t *testing.T) {
+	addr := freeAddr(t)
+
+	s := NewWebsocketServer(WsOption{})
+	go func() { _ = s.Serve(addr) }()
+	time.Sleep(50 * time.Millisecond)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws://"+addr+"/", nil)
+	if err != nil {
+		t.Fatalf("dial err: %s", err)
+	}
+	defer conn.Close()
+	time.Sleep(50 * time.Millisecond) // let handleConn register the session
+
+	session.Sessions().Range(func(id string, sess session.Session) (next bool) {
+		if wsSess, ok := sess.(*session.WsSession); ok {
+			wsSess.Close()
+		}
+		return true
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown err: %s", err)
+	}
+}
+
+func TestWebsocketServer_Stop_ClosesHttpServer(t *testing.T) {
+	addr := freeAddr(t)
+
+	s := NewWebsocketServer(WsOption{})
+	go func() { _ = s.Serve(addr) }()
+	time.Sleep(50 * time.Millisecond)
+
+	if err := s.Stop(); err != nil {
+		t.Fatalf("stop err: %s", err)
+	}
+}
@@ -0,0 +1,125 @@
+package session
+
+import (
+	"github.com/DarthPestilane/easytcp/logger"
+	"github.com/DarthPestilane/easytcp/packet"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"net"
+	"sync"
+)
+
+// UdpSession represents one logical peer on a shared *net.UDPConn, keyed by
+// remote address. The server's read loop demultiplexes inbound packets
+// into the matching session's inChan; WriteLoop writes back out via the
+// shared conn using WriteToUDP.
+type UdpSession struct {
+	id         string
+	conn       *net.UDPConn
+	remoteAddr *net.UDPAddr
+	msgPacker  packet.Packer
+	msgCodec   packet.Codec
+	inChan     chan *packet.Message
+	outChan    chan *packet.Message
+	closeChan  chan struct{}
+	closeOnce  sync.Once
+	log        *logrus.Entry
+}
+
+// NewUdp creates a new UdpSession for packets arriving from remoteAddr on
+// the shared conn.
+func NewUdp(conn *net.UDPConn, remoteAddr *net.UDPAddr, packer packet.Packer, codec packet.Codec) *UdpSession {
+	return &UdpSession{
+		id:         uuid.New().String(),
+		conn:       conn,
+		remoteAddr: remoteAddr,
+		msgPacker:  packer,
+		msgCodec:   codec,
+		inChan:     make(chan *packet.Message, 1024),
+		outChan:    make(chan *packet.Message, 1024),
+		closeChan:  make(chan struct{}),
+		log:        logger.Default.WithField("scope", "session.UdpSession"),
+	}
+}
+
+func (s *UdpSession) ID() string {
+	return s.id
+}
+
+// RemoteAddr returns the address this session is keyed by.
+func (s *UdpSession) RemoteAddr() *net.UDPAddr {
+	return s.remoteAddr
+}
+
+// MsgChan returns the channel the router reads incoming messages from.
+func (s *UdpSession) MsgChan() <-chan *packet.Message {
+	return s.inChan
+}
+
+// Dispatch is called by the server's read loop for every datagram that
+// belongs to this session.
+func (s *UdpSession) Dispatch(data []byte) {
+	msg, err := s.msgPacker.Unpack(data)
+	if err != nil {
+		s.log.Tracef("unpack message err: %s", err)
+		return
+	}
+	select {
+	case s.inChan <- msg:
+	case <-s.closeChan:
+	}
+}
+
+// Send enqueues msg to be written out by WriteLoop.
+func (s *UdpSession) Send(msg *packet.Message) {
+	select {
+	case s.outChan <- msg:
+	case <-s.closeChan:
+	}
+}
+
+// ReadLoop exists to satisfy the Session interface. Reading happens on the
+// server's shared conn and is routed to Dispatch, so there's nothing to do
+// here beyond waiting for the session to close.
+func (s *UdpSession) ReadLoop() {
+	<-s.closeChan
+}
+
+// WriteLoop packs queued messages and writes them back to remoteAddr over
+// the shared conn until the session is closed.
+func (s *UdpSession) WriteLoop() {
+	for {
+		select {
+		case msg := <-s.outChan:
+			data, err := s.msgPacker.Pack(msg)
+			if err != nil {
+				s.log.Tracef("pack message err: %s", err)
+				continue
+			}
+			if _, err := s.conn.WriteToUDP(data, s.remoteAddr); err != nil {
+				s.log.Tracef("write to udp err: %s", err)
+			}
+		case <-s.closeChan:
+			return
+		}
+	}
+}
+
+// WaitUntilClosed blocks until the session has been closed.
+func (s *UdpSession) WaitUntilClosed() {
+	<-s.closeChan
+}
+
+// Close marks the session closed. The shared conn is owned by the server,
+// not the session, so it's left open. It's safe to call more than once.
+func (s *UdpSession) Close() {
+	s.closeOnce.Do(func() {
+		close(s.closeChan)
+	})
+}
+
+// Codec returns the message codec associated with this session, for
+// handlers that need to decode/encode message payloads.
+func (s *UdpSession) Codec() packet.Codec {
+	return s.msgCodec
+}
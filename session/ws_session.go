@@ -0,0 +1,123 @@
+package session
+
+import (
+	"github.com/DarthPestilane/easytcp/logger"
+	"github.com/DarthPestilane/easytcp/packet"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+	"sync"
+)
+
+// WsSession wraps a *websocket.Conn so it can be routed and tracked the same
+// way a TcpSession is: read/write loops pump packet.Message values through
+// the session and the router dispatches whatever ReadLoop decodes.
+type WsSession struct {
+	id        string
+	conn      *websocket.Conn
+	msgPacker packet.Packer
+	msgCodec  packet.Codec
+	inChan    chan *packet.Message
+	outChan   chan *packet.Message
+	closeChan chan struct{}
+	closeOnce sync.Once
+	log       *logrus.Entry
+}
+
+// NewWs creates a new WsSession around conn.
+func NewWs(conn *websocket.Conn, packer packet.Packer, codec packet.Codec) *WsSession {
+	return &WsSession{
+		id:        uuid.New().String(),
+		conn:      conn,
+		msgPacker: packer,
+		msgCodec:  codec,
+		inChan:    make(chan *packet.Message, 1024),
+		outChan:   make(chan *packet.Message, 1024),
+		closeChan: make(chan struct{}),
+		log:       logger.Default.WithField("scope", "session.WsSession"),
+	}
+}
+
+func (s *WsSession) ID() string {
+	return s.id
+}
+
+// MsgChan returns the channel the router reads incoming messages from.
+func (s *WsSession) MsgChan() <-chan *packet.Message {
+	return s.inChan
+}
+
+// Send enqueues msg to be written out by WriteLoop.
+func (s *WsSession) Send(msg *packet.Message) {
+	select {
+	case s.outChan <- msg:
+	case <-s.closeChan:
+	}
+}
+
+// ReadLoop reads binary frames off the websocket connection, unpacks them
+// into packet.Messages and forwards them to the router. Each websocket frame
+// carries exactly one packed message, since the transport already frames
+// messages for us.
+func (s *WsSession) ReadLoop() {
+	defer s.Close()
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		msg, err := s.msgPacker.Unpack(data)
+		if err != nil {
+			s.log.Tracef("unpack message err: %s", err)
+			continue
+		}
+		select {
+		case s.inChan <- msg:
+		case <-s.closeChan:
+			return
+		}
+	}
+}
+
+// WriteLoop packs queued messages and writes them to the websocket
+// connection as binary frames until the session is closed.
+func (s *WsSession) WriteLoop() {
+	for {
+		select {
+		case msg := <-s.outChan:
+			data, err := s.msgPacker.Pack(msg)
+			if err != nil {
+				s.log.Tracef("pack message err: %s", err)
+				continue
+			}
+			if err := s.conn.WriteMessage(websocket.BinaryMessage, data); err != nil {
+				s.Close()
+				return
+			}
+		case <-s.closeChan:
+			return
+		}
+	}
+}
+
+// Codec returns the message codec associated with this session, for
+// handlers that need to decode/encode message payloads.
+func (s *WsSession) Codec() packet.Codec {
+	return s.msgCodec
+}
+
+// WaitUntilClosed blocks until the session has been closed.
+func (s *WsSession) WaitUntilClosed() {
+	<-s.closeChan
+}
+
+// Close closes the underlying websocket connection and unblocks
+// WaitUntilClosed. It's safe to call more than once.
+func (s *WsSession) Close() {
+	s.closeOnce.Do(func() {
+		close(s.closeChan)
+		if err := s.conn.Close(); err != nil {
+			s.log.Tracef("ws conn close err: %s", err)
+		}
+	})
+}